@@ -0,0 +1,34 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package containers implements `ctr containers`, general-purpose
+// inspection and lifecycle commands for containers regardless of how
+// they were created (`ctr run`, `ctr pod create`, or otherwise).
+package containers
+
+import (
+	"github.com/urfave/cli"
+)
+
+// Command is the parent command for `ctr containers` subcommands.
+var Command = cli.Command{
+	Name:    "containers",
+	Usage:   "manage containers",
+	Aliases: []string{"c"},
+	Subcommands: cli.Commands{
+		rmCommand,
+	},
+}
@@ -0,0 +1,70 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package containers
+
+import (
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cmd/ctr/commands"
+	"github.com/containerd/containerd/cmd/ctr/commands/imagemount"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var rmCommand = cli.Command{
+	Name:      "rm",
+	Usage:     "delete one or more existing containers",
+	ArgsUsage: "[ID...]",
+	Action: func(context *cli.Context) error {
+		ids := []string(context.Args())
+		if len(ids) == 0 {
+			return errors.New("must specify at least one container id")
+		}
+
+		client, ctx, cancel, err := commands.NewClient(context)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		for _, id := range ids {
+			container, err := client.LoadContainer(ctx, id)
+			if err != nil {
+				return errors.Wrapf(err, "load container %s", id)
+			}
+			if task, err := container.Task(ctx, nil); err == nil {
+				if _, err := task.Delete(ctx, containerd.WithProcessKill); err != nil && !errdefs.IsNotFound(err) {
+					return errors.Wrapf(err, "delete task %s", id)
+				}
+			} else if !errdefs.IsNotFound(err) {
+				return errors.Wrapf(err, "load task %s", id)
+			}
+			// Every path that deletes a container - this one, `ctr run
+			// --rm`, and `ctr pod rm` - must tear down any ephemeral
+			// image-mount views it holds (see imagemount.Remove), or a
+			// container started with --detach and `--mount type=image`
+			// leaks its snapshotter view forever.
+			if err := imagemount.Remove(ctx, client, container); err != nil {
+				return errors.Wrapf(err, "remove ephemeral image-mount snapshots for %s", id)
+			}
+			if err := container.Delete(ctx, containerd.WithSnapshotCleanup); err != nil {
+				return errors.Wrapf(err, "delete container %s", id)
+			}
+		}
+		return nil
+	},
+}
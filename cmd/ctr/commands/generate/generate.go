@@ -0,0 +1,34 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package generate renders auxiliary artifacts - systemd units,
+// Kubernetes manifests - from containers already known to containerd,
+// so they can be reproduced by tooling outside of ctr.
+package generate
+
+import (
+	"github.com/urfave/cli"
+)
+
+// Command is the parent command for `ctr generate` subcommands.
+var Command = cli.Command{
+	Name:  "generate",
+	Usage: "generate auxiliary artifacts from an existing container",
+	Subcommands: cli.Commands{
+		systemdCommand,
+		kubeCommand,
+	},
+}
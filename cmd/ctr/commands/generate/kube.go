@@ -0,0 +1,418 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package generate
+
+import (
+	gocontext "context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cmd/ctr/commands"
+	"github.com/containerd/containerd/cmd/ctr/commands/pod"
+	"github.com/containerd/containerd/cmd/ctr/commands/run"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+var kubeCommand = cli.Command{
+	Name:      "kube",
+	Usage:     "generate a Kubernetes Pod (or, with --type, Deployment or DaemonSet) manifest from one or more containers, without requiring their tasks to be running",
+	ArgsUsage: "CONTAINER [CONTAINER...]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "pod",
+			Usage: "generate from every member of a pod created with `ctr pod create` instead of explicit container ids",
+		},
+		cli.StringFlag{
+			Name:  "name",
+			Usage: "name of the generated manifest, defaults to the first container id",
+		},
+		cli.StringFlag{
+			Name:  "type",
+			Usage: "manifest kind to emit for the container group (pod|deployment|daemonset)",
+			Value: "pod",
+		},
+		cli.BoolFlag{
+			Name:  "service",
+			Usage: "also emit a Service manifest for any container ports declared on the spec",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		client, ctx, cancel, err := commands.NewClient(context)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		ids, err := containerIDs(ctx, client, context)
+		if err != nil {
+			return err
+		}
+
+		name := context.String("name")
+		if name == "" {
+			name = ids[0]
+		}
+		name = kubeName(name)
+
+		var (
+			podSpec corev1.PodSpec
+			ports   []corev1.ContainerPort
+		)
+		for _, id := range ids {
+			container, err := client.LoadContainer(ctx, id)
+			if err != nil {
+				return errors.Wrapf(err, "load container %s", id)
+			}
+			c, vols, err := kubeContainer(ctx, container)
+			if err != nil {
+				return errors.Wrapf(err, "convert container %s", id)
+			}
+			podSpec.Containers = append(podSpec.Containers, c)
+			podSpec.Volumes = append(podSpec.Volumes, vols...)
+			ports = append(ports, c.Ports...)
+		}
+
+		template := corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"app": name}},
+			Spec:       podSpec,
+		}
+
+		var objs []interface{}
+		switch kind := context.String("type"); kind {
+		case "", "pod":
+			objs = append(objs, &corev1.Pod{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+				ObjectMeta: template.ObjectMeta,
+				Spec:       podSpec,
+			})
+		case "deployment":
+			replicas := int32(1)
+			objs = append(objs, &appsv1.Deployment{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: &replicas,
+					Selector: &metav1.LabelSelector{MatchLabels: template.ObjectMeta.Labels},
+					Template: template,
+				},
+			})
+		case "daemonset":
+			objs = append(objs, &appsv1.DaemonSet{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"},
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: appsv1.DaemonSetSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: template.ObjectMeta.Labels},
+					Template: template,
+				},
+			})
+		default:
+			return errors.Errorf("unknown --type %q, want pod, deployment, or daemonset", kind)
+		}
+
+		if context.Bool("service") && len(ports) > 0 {
+			objs = append(objs, kubeService(name, ports))
+		}
+
+		for i, obj := range objs {
+			if i > 0 {
+				fmt.Fprintln(context.App.Writer, "---")
+			}
+			b, err := yaml.Marshal(obj)
+			if err != nil {
+				return err
+			}
+			if _, err := context.App.Writer.Write(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// containerIDs resolves the set of container ids to generate a
+// manifest from: either the explicit arguments, or every member of
+// --pod (infra container included, since it already holds the shared
+// network namespace's declared ports).
+func containerIDs(ctx gocontext.Context, client *containerd.Client, context *cli.Context) ([]string, error) {
+	if podID := context.String("pod"); podID != "" {
+		containers, err := client.Containers(ctx, fmt.Sprintf("labels.%q==%q", pod.LabelPodID, podID))
+		if err != nil {
+			return nil, errors.Wrapf(err, "list pod %s containers", podID)
+		}
+		if len(containers) == 0 {
+			return nil, errors.Errorf("pod %s has no containers", podID)
+		}
+		ids := make([]string, len(containers))
+		for i, c := range containers {
+			ids[i] = c.ID()
+		}
+		return ids, nil
+	}
+
+	ids := []string(context.Args())
+	if len(ids) == 0 {
+		return nil, errors.New("at least one container id, or --pod, must be provided")
+	}
+	return ids, nil
+}
+
+// kubeContainer converts a containerd container's stored spec into a
+// corev1.Container plus the corev1.Volumes its mounts reference,
+// without requiring the container's task to be running.
+func kubeContainer(ctx gocontext.Context, container containerd.Container) (corev1.Container, []corev1.Volume, error) {
+	info, err := container.Info(ctx)
+	if err != nil {
+		return corev1.Container{}, nil, err
+	}
+	spec, err := container.Spec(ctx)
+	if err != nil {
+		return corev1.Container{}, nil, err
+	}
+	if spec.Process == nil {
+		return corev1.Container{}, nil, errors.New("container spec has no process")
+	}
+
+	var namedVolumes map[string]string
+	if encoded, ok := info.Labels[run.VolumeMountLabel]; ok {
+		if err := yaml.Unmarshal([]byte(encoded), &namedVolumes); err != nil {
+			return corev1.Container{}, nil, errors.Wrap(err, "decode volume mount label")
+		}
+	}
+	ephemeralDests := make(map[string]bool)
+	for _, dest := range strings.Split(info.Labels[run.ImageMountDestsLabel], ",") {
+		if dest != "" {
+			ephemeralDests[dest] = true
+		}
+	}
+
+	c := corev1.Container{
+		Name:    kubeName(container.ID()),
+		Image:   info.Image,
+		Command: spec.Process.Args,
+		Env:     kubeEnv(spec.Process.Env),
+		Ports:   kubePorts(spec.Annotations[portsAnnotation]),
+	}
+
+	if spec.Process.User.UID != 0 {
+		uid := int64(spec.Process.User.UID)
+		c.SecurityContext = &corev1.SecurityContext{RunAsUser: &uid}
+	}
+	if spec.Process.Capabilities != nil && len(spec.Process.Capabilities.Bounding) > 0 {
+		if c.SecurityContext == nil {
+			c.SecurityContext = &corev1.SecurityContext{}
+		}
+		caps := make([]corev1.Capability, 0, len(spec.Process.Capabilities.Bounding))
+		for _, cap := range spec.Process.Capabilities.Bounding {
+			caps = append(caps, corev1.Capability(strings.TrimPrefix(cap, "CAP_")))
+		}
+		c.SecurityContext.Capabilities = &corev1.Capabilities{Add: caps}
+	}
+
+	if spec.Linux != nil && spec.Linux.Resources != nil {
+		c.Resources = kubeResources(spec.Linux.Resources)
+	}
+
+	var volumes []corev1.Volume
+	for i, m := range spec.Mounts {
+		if defaultMountDests[m.Destination] {
+			// Part of the OCI default spec every container gets
+			// regardless of --mount flags, not something the user
+			// asked for - see defaultMountDests in reconstruct.go.
+			continue
+		}
+		// type=image resolves to a snapshotter-specific mount (e.g.
+		// type=overlay for the default snapshotter), so it must be
+		// matched on ImageMountDestsLabel rather than m.Type before
+		// the bind/tmpfs whitelist below, or it's silently dropped.
+		volName := fmt.Sprintf("%s-mnt-%d", c.Name, i)
+		readOnly := hasOption(m.Options, "ro")
+		if ephemeralDests[m.Destination] {
+			// Backed by a snapshotter view removed once the source
+			// container is deleted (see imagemount.Remove) - not a
+			// stable host path, so emptyDir is the closest match.
+			volumes = append(volumes, corev1.Volume{
+				Name:         volName,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			})
+			c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+				Name:      volName,
+				MountPath: m.Destination,
+				ReadOnly:  readOnly,
+			})
+			continue
+		}
+		if m.Type != "bind" && m.Type != "tmpfs" {
+			continue
+		}
+
+		if volumeName, ok := namedVolumes[m.Destination]; ok && volumeName != "" {
+			volumes = append(volumes, corev1.Volume{
+				Name: volName,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: volumeName,
+						ReadOnly:  readOnly,
+					},
+				},
+			})
+		} else if m.Type == "tmpfs" {
+			vs := corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}}
+			if size := optionValue(m.Options, "size"); size != "" {
+				if q, err := resource.ParseQuantity(size); err == nil {
+					vs.EmptyDir.SizeLimit = &q
+				}
+			}
+			volumes = append(volumes, corev1.Volume{Name: volName, VolumeSource: vs})
+		} else {
+			volumes = append(volumes, corev1.Volume{
+				Name: volName,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{Path: m.Source},
+				},
+			})
+		}
+
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      volName,
+			MountPath: m.Destination,
+			ReadOnly:  readOnly,
+		})
+	}
+
+	return c, volumes, nil
+}
+
+// portsAnnotation lets a container declare the ports `ctr generate
+// kube --service` should publish, since the OCI runtime spec itself
+// has no concept of a port: a comma-separated list of
+// port[/protocol], e.g. "8080/tcp,9090/udp".
+const portsAnnotation = "io.containerd.ports"
+
+func kubePorts(declared string) []corev1.ContainerPort {
+	if declared == "" {
+		return nil
+	}
+	var ports []corev1.ContainerPort
+	for _, p := range strings.Split(declared, ",") {
+		portProto := strings.SplitN(p, "/", 2)
+		port, err := strconv.Atoi(portProto[0])
+		if err != nil {
+			continue
+		}
+		proto := corev1.ProtocolTCP
+		if len(portProto) == 2 && strings.EqualFold(portProto[1], "udp") {
+			proto = corev1.ProtocolUDP
+		}
+		ports = append(ports, corev1.ContainerPort{ContainerPort: int32(port), Protocol: proto})
+	}
+	return ports
+}
+
+func kubeEnv(env []string) []corev1.EnvVar {
+	var out []corev1.EnvVar
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		out = append(out, corev1.EnvVar{Name: kv[0], Value: kv[1]})
+	}
+	return out
+}
+
+func kubeResources(r *specs.LinuxResources) corev1.ResourceRequirements {
+	limits := corev1.ResourceList{}
+	if r.Memory != nil && r.Memory.Limit != nil && *r.Memory.Limit > 0 {
+		limits[corev1.ResourceMemory] = *resource.NewQuantity(*r.Memory.Limit, resource.BinarySI)
+	}
+	if r.CPU != nil && r.CPU.Quota != nil && *r.CPU.Quota > 0 && r.CPU.Period != nil && *r.CPU.Period > 0 {
+		milliCPU := *r.CPU.Quota * 1000 / int64(*r.CPU.Period)
+		limits[corev1.ResourceCPU] = *resource.NewMilliQuantity(milliCPU, resource.DecimalSI)
+	}
+	if len(limits) == 0 {
+		return corev1.ResourceRequirements{}
+	}
+	return corev1.ResourceRequirements{Limits: limits}
+}
+
+func kubeService(name string, ports []corev1.ContainerPort) *corev1.Service {
+	svc := &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": name},
+		},
+	}
+	for _, p := range ports {
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:       strconv.Itoa(int(p.ContainerPort)),
+			Port:       p.ContainerPort,
+			TargetPort: intstr.FromInt(int(p.ContainerPort)),
+			Protocol:   p.Protocol,
+		})
+	}
+	return svc
+}
+
+// kubeName lowercases id and replaces every run of characters outside
+// Kubernetes' DNS-1123 label charset (a-z0-9-) with a single "-", so
+// container ids containing uppercase letters or underscores - both
+// legal in containerd - still produce a manifest kubectl will accept.
+func kubeName(id string) string {
+	var b strings.Builder
+	prevDash := false
+	for _, r := range strings.ToLower(id) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			prevDash = false
+		case !prevDash:
+			b.WriteByte('-')
+			prevDash = true
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+func hasOption(options []string, want string) bool {
+	for _, o := range options {
+		if o == want {
+			return true
+		}
+	}
+	return false
+}
+
+func optionValue(options []string, key string) string {
+	prefix := key + "="
+	for _, o := range options {
+		if strings.HasPrefix(o, prefix) {
+			return strings.TrimPrefix(o, prefix)
+		}
+	}
+	return ""
+}
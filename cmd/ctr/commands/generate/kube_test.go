@@ -0,0 +1,106 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package generate
+
+import (
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestKubeName(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"my-container", "my-container"},
+		{"MyContainer", "mycontainer"},
+		{"under_score", "under-score"},
+		{"--leading-and-trailing--", "leading-and-trailing"},
+		{"a...b", "a-b"},
+		{"a1b2c3", "a1b2c3"},
+	}
+	for _, tt := range tests {
+		if got := kubeName(tt.id); got != tt.want {
+			t.Errorf("kubeName(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func int64Ptr(v int64) *int64    { return &v }
+func uint64Ptr(v uint64) *uint64 { return &v }
+
+func TestKubeResourcesCPUUnlimited(t *testing.T) {
+	r := &specs.LinuxResources{
+		CPU: &specs.LinuxCPU{
+			Quota:  int64Ptr(-1),
+			Period: uint64Ptr(100000),
+		},
+	}
+	resources := kubeResources(r)
+	if _, ok := resources.Limits["cpu"]; ok {
+		t.Errorf("kubeResources with quota=-1 (unlimited) set a cpu limit: %v", resources.Limits)
+	}
+}
+
+func TestKubeResourcesCPULimited(t *testing.T) {
+	r := &specs.LinuxResources{
+		CPU: &specs.LinuxCPU{
+			Quota:  int64Ptr(50000),
+			Period: uint64Ptr(100000),
+		},
+	}
+	resources := kubeResources(r)
+	cpu, ok := resources.Limits["cpu"]
+	if !ok {
+		t.Fatalf("kubeResources with quota=50000/period=100000 set no cpu limit")
+	}
+	if got := cpu.MilliValue(); got != 500 {
+		t.Errorf("cpu limit = %dm, want 500m", got)
+	}
+}
+
+func TestKubeResourcesMemory(t *testing.T) {
+	r := &specs.LinuxResources{
+		Memory: &specs.LinuxMemory{Limit: int64Ptr(128 << 20)},
+	}
+	resources := kubeResources(r)
+	mem, ok := resources.Limits["memory"]
+	if !ok {
+		t.Fatalf("kubeResources with a memory limit set no memory limit")
+	}
+	if got := mem.Value(); got != 128<<20 {
+		t.Errorf("memory limit = %d, want %d", got, 128<<20)
+	}
+}
+
+func TestKubeResourcesMemoryUnlimited(t *testing.T) {
+	r := &specs.LinuxResources{
+		Memory: &specs.LinuxMemory{Limit: int64Ptr(-1)},
+	}
+	resources := kubeResources(r)
+	if _, ok := resources.Limits["memory"]; ok {
+		t.Errorf("kubeResources with memory limit=-1 (unlimited) set a memory limit: %v", resources.Limits)
+	}
+}
+
+func TestKubeResourcesEmpty(t *testing.T) {
+	resources := kubeResources(&specs.LinuxResources{})
+	if len(resources.Limits) != 0 {
+		t.Errorf("kubeResources with no limits set returned limits: %v", resources.Limits)
+	}
+}
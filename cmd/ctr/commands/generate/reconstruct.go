@@ -0,0 +1,187 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package generate
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"strings"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cmd/ctr/commands/run"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+)
+
+// runInvocation captures everything needed to re-issue the `ctr run`
+// invocation that originally created a container.
+type runInvocation struct {
+	Image       string
+	Snapshotter string
+	Mounts      []string
+	Env         []string
+	CgroupPath  string
+	Args        []string
+}
+
+// reconstructRunInvocation reads back a container's stored metadata
+// and OCI spec and turns it into the `ctr run` flags that would
+// recreate an equivalent container.
+func reconstructRunInvocation(ctx gocontext.Context, container containerd.Container) (*runInvocation, error) {
+	info, err := container.Info(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "load container info")
+	}
+	spec, err := container.Spec(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "load container spec")
+	}
+	if spec.Process == nil {
+		return nil, errors.New("container spec has no process")
+	}
+
+	ri := &runInvocation{
+		Image:       info.Image,
+		Snapshotter: info.Snapshotter,
+		Env:         spec.Process.Env,
+		Args:        spec.Process.Args,
+	}
+	if spec.Linux != nil {
+		ri.CgroupPath = spec.Linux.CgroupsPath
+	}
+
+	var imageSourcesByDest map[string]string
+	if encoded := info.Labels[run.ImageMountSourcesLabel]; encoded != "" {
+		if err := json.Unmarshal([]byte(encoded), &imageSourcesByDest); err != nil {
+			return nil, errors.Wrap(err, "decode image mount sources label")
+		}
+	}
+	var volumesByDest map[string]string
+	if encoded := info.Labels[run.VolumeMountLabel]; encoded != "" {
+		if err := json.Unmarshal([]byte(encoded), &volumesByDest); err != nil {
+			return nil, errors.Wrap(err, "decode volume mount label")
+		}
+	}
+	for _, m := range spec.Mounts {
+		if ref, ok := imageSourcesByDest[m.Destination]; ok {
+			ri.Mounts = append(ri.Mounts, imageMountFlagValue(ref, m.Destination))
+			continue
+		}
+		if volumeName, ok := volumesByDest[m.Destination]; ok {
+			ri.Mounts = append(ri.Mounts, volumeMountFlagValue(volumeName, m.Destination, m.Options))
+			continue
+		}
+		if defaultMountDests[m.Destination] {
+			continue
+		}
+		ri.Mounts = append(ri.Mounts, mountFlagValue(m))
+	}
+	return ri, nil
+}
+
+// defaultMountDests holds the destinations of the OCI default spec
+// mounts every `ctr run` container gets regardless of --mount flags
+// (see oci.WithDefaultSpec / oci.WithDefaultUnixDevices). run already
+// re-adds these itself, so reconstructing them as --mount flags would
+// either be rejected outright by parseMountFlag's type/bind/tmpfs/
+// volume/image whitelist (proc, sysfs, devpts, mqueue, cgroup) or, for
+// the tmpfs ones, duplicate the mounts run creates on its own.
+var defaultMountDests = map[string]bool{
+	"/proc":          true,
+	"/dev":           true,
+	"/dev/pts":       true,
+	"/dev/shm":       true,
+	"/dev/mqueue":    true,
+	"/sys":           true,
+	"/sys/fs/cgroup": true,
+}
+
+// mountFlagValue renders m back into the CSV syntax accepted by
+// run.Command's --mount flag (see parseMountFlag), so that a generated
+// unit stays stable across repeated `ctr generate systemd` runs.
+func mountFlagValue(m specs.Mount) string {
+	fields := []string{"type=" + m.Type}
+	// parseMountFlag rejects a source key on type=tmpfs.
+	if m.Type != "tmpfs" {
+		fields = append(fields, "source="+m.Source)
+	}
+	fields = append(fields, "destination="+m.Destination)
+	if len(m.Options) > 0 {
+		fields = append(fields, "options="+strings.Join(m.Options, ":"))
+	}
+	return strings.Join(fields, ",")
+}
+
+// imageMountFlagValue renders a `--mount type=image` flag for a mount
+// whose destination was recorded under run.ImageMountSourcesLabel.
+// spec.Mounts holds the resolved, ephemeral snapshotter view for this
+// destination instead, which is torn down on container delete and
+// must not be reconstructed verbatim (see run.ImageMountSourcesLabel).
+func imageMountFlagValue(ref, destination string) string {
+	return strings.Join([]string{
+		"type=image",
+		"source=" + ref,
+		"destination=" + destination,
+	}, ",")
+}
+
+// volumeMountFlagValue renders a `--mount type=volume` flag for a
+// mount whose destination was recorded under run.VolumeMountLabel.
+// spec.Mounts holds the resolved bind mount for this destination
+// instead, which is only stable as long as the named volume isn't
+// recreated at a different path (see run.VolumeMountLabel). options
+// is that resolved mount's Options; every option except "rbind" is
+// preserved, since "rbind" is parseMountFlag's own derived artifact of
+// resolving type=volume to a bind mount, and it appends it again.
+func volumeMountFlagValue(volumeName, destination string, options []string) string {
+	fields := []string{
+		"type=volume",
+		"source=" + volumeName,
+		"destination=" + destination,
+	}
+	var kept []string
+	for _, o := range options {
+		if o != "rbind" {
+			kept = append(kept, o)
+		}
+	}
+	if len(kept) > 0 {
+		fields = append(fields, "options="+strings.Join(kept, ":"))
+	}
+	return strings.Join(fields, ",")
+}
+
+// runArgs renders ri as the argument list of a `ctr run` invocation
+// that creates a container named id.
+func (ri *runInvocation) runArgs(id string) []string {
+	args := []string{"run"}
+	if ri.Snapshotter != "" {
+		args = append(args, "--snapshotter", ri.Snapshotter)
+	}
+	if ri.CgroupPath != "" {
+		args = append(args, "--cgroup", ri.CgroupPath)
+	}
+	for _, m := range ri.Mounts {
+		args = append(args, "--mount", m)
+	}
+	for _, e := range ri.Env {
+		args = append(args, "--env", e)
+	}
+	args = append(args, ri.Image, id)
+	args = append(args, ri.Args...)
+	return args
+}
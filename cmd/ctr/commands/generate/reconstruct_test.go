@@ -0,0 +1,91 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package generate
+
+import (
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestMountFlagValue(t *testing.T) {
+	tests := []struct {
+		name string
+		m    specs.Mount
+		want string
+	}{
+		{
+			name: "bind with options",
+			m: specs.Mount{
+				Type:        "bind",
+				Source:      "/host/path",
+				Destination: "/container/path",
+				Options:     []string{"rbind", "ro"},
+			},
+			want: "type=bind,source=/host/path,destination=/container/path,options=rbind:ro",
+		},
+		{
+			name: "no options",
+			m: specs.Mount{
+				Type:        "tmpfs",
+				Destination: "/tmp",
+			},
+			want: "type=tmpfs,destination=/tmp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mountFlagValue(tt.m); got != tt.want {
+				t.Errorf("mountFlagValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageMountFlagValue(t *testing.T) {
+	want := "type=image,source=docker.io/library/nginx:latest,destination=/usr/share/nginx/html"
+	got := imageMountFlagValue("docker.io/library/nginx:latest", "/usr/share/nginx/html")
+	if got != want {
+		t.Errorf("imageMountFlagValue() = %q, want %q", got, want)
+	}
+}
+
+func TestVolumeMountFlagValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []string
+		want    string
+	}{
+		{
+			name:    "no options",
+			options: nil,
+			want:    "type=volume,source=myvol,destination=/data",
+		},
+		{
+			name:    "readonly preserved",
+			options: []string{"rbind", "ro"},
+			want:    "type=volume,source=myvol,destination=/data,options=ro",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := volumeMountFlagValue("myvol", "/data", tt.options); got != tt.want {
+				t.Errorf("volumeMountFlagValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
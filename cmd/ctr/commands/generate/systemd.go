@@ -0,0 +1,165 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package generate
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/containerd/containerd/cmd/ctr/commands"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var systemdCommand = cli.Command{
+	Name:      "systemd",
+	Usage:     "generate a systemd unit file that manages a container via ctr run",
+	ArgsUsage: "CONTAINER",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "restart-policy",
+			Usage: "value of the unit's Restart=",
+			Value: "on-failure",
+		},
+		cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "time to wait for the container to stop before the unit is considered failed",
+			Value: 10 * time.Second,
+		},
+		cli.BoolFlag{
+			Name:  "new",
+			Usage: "remove and recreate the container on every start instead of reusing it across restarts",
+		},
+		cli.StringFlag{
+			Name:  "pid-file",
+			Usage: "path passed to ctr run --pid-file and referenced by the unit's PIDFile=; ignored unless --new is set",
+		},
+		cli.StringFlag{
+			Name:  "description",
+			Usage: "value of the unit's Description=",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		id := context.Args().First()
+		if id == "" {
+			return errors.New("container id must be provided")
+		}
+
+		client, ctx, cancel, err := commands.NewClient(context)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		container, err := client.LoadContainer(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		ri, err := reconstructRunInvocation(ctx, container)
+		if err != nil {
+			return errors.Wrap(err, "reconstruct ctr run invocation")
+		}
+
+		unit, err := renderSystemdUnit(id, ri, context)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(context.App.Writer, unit)
+		return err
+	},
+}
+
+const systemdUnitTemplate = `[Unit]
+Description={{.Description}}
+After=network.target
+
+[Service]
+Type=simple
+{{- if .New}}
+ExecStartPre=-/usr/bin/ctr c rm {{.ID}}
+ExecStart=/usr/bin/ctr {{.RunArgs}}
+{{- else}}
+ExecStartPre=-/usr/bin/ctr t kill --signal SIGKILL {{.ID}}
+ExecStartPre=-/usr/bin/ctr t rm {{.ID}}
+ExecStart=/usr/bin/ctr t start {{.ID}}
+{{- end}}
+ExecStop=-/usr/bin/ctr t kill --signal SIGTERM {{.ID}}
+{{- if .New}}
+ExecStopPost=-/usr/bin/ctr c rm {{.ID}}
+{{- end}}
+TimeoutStopSec={{.TimeoutSec}}
+Restart={{.RestartPolicy}}
+{{- if .PIDFile}}
+PIDFile={{.PIDFile}}
+{{- end}}
+
+[Install]
+WantedBy=multi-user.target
+`
+
+type systemdUnitData struct {
+	ID            string
+	Description   string
+	RunArgs       string
+	RestartPolicy string
+	TimeoutSec    int
+	PIDFile       string
+	New           bool
+}
+
+func renderSystemdUnit(id string, ri *runInvocation, context *cli.Context) (string, error) {
+	args := ri.runArgs(id)
+	if pidFile := context.String("pid-file"); pidFile != "" && context.Bool("new") {
+		// ctr run stops parsing flags at the image ref, so --pid-file
+		// must come right after "run", ahead of the positional args.
+		args = append([]string{"run", "--pid-file", pidFile}, args[1:]...)
+	}
+
+	description := context.String("description")
+	if description == "" {
+		description = fmt.Sprintf("containerd container %s", id)
+	}
+
+	new := context.Bool("new")
+	var pidFile string
+	if new {
+		pidFile = context.String("pid-file")
+	}
+
+	data := systemdUnitData{
+		ID:            id,
+		Description:   description,
+		RunArgs:       strings.Join(args, " "),
+		RestartPolicy: context.String("restart-policy"),
+		TimeoutSec:    int(context.Duration("timeout") / time.Second),
+		PIDFile:       pidFile,
+		New:           new,
+	}
+
+	tmpl, err := template.New("systemd-unit").Parse(systemdUnitTemplate)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,87 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"fmt"
+
+	"github.com/containerd/containerd/cmd/ctr/commands"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+// Command is the parent command for inspecting and re-executing the
+// health probes stored on containers created with `ctr run --health-cmd`.
+var Command = cli.Command{
+	Name:  "healthcheck",
+	Usage: "run and inspect container health checks",
+	Subcommands: cli.Commands{
+		runCommand,
+	},
+}
+
+var runCommand = cli.Command{
+	Name:      "run",
+	Usage:     "execute the health probe stored on a container and update its status",
+	ArgsUsage: "CONTAINER",
+	Action: func(context *cli.Context) error {
+		id := context.Args().First()
+		if id == "" {
+			return errors.New("container id must be provided")
+		}
+
+		client, ctx, cancel, err := commands.NewClient(context)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		container, err := client.LoadContainer(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		info, err := container.Info(ctx)
+		if err != nil {
+			return err
+		}
+		encoded, ok := info.Labels[ProbeLabel]
+		if !ok {
+			return errors.Errorf("container %s has no health probe configured", id)
+		}
+		probe, err := DecodeProbe(encoded)
+		if err != nil {
+			return errors.Wrap(err, "decode health probe")
+		}
+
+		task, err := container.Task(ctx, nil)
+		if err != nil {
+			return errors.Wrap(err, "load task")
+		}
+
+		status, err := RunOnce(ctx, client, container, task, probe)
+		if err != nil {
+			return err
+		}
+		recordStatus(ctx, client, container, status)
+		fmt.Println(status)
+		if status == StatusUnhealthy {
+			return cli.NewExitError("", 1)
+		}
+		return nil
+	},
+}
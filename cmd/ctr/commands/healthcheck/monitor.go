@@ -0,0 +1,232 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	gocontext "context"
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/cmd/ctr/commands/tasks"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// HealthEvent is published to the containerd event bus whenever a
+// probe run changes a container's recorded health status.
+type HealthEvent struct {
+	ContainerID string `json:"container_id"`
+	Status      string `json:"status"`
+}
+
+// RestartHandoff lets Monitor coordinate an --on-unhealthy=restart
+// with a caller that is concurrently waiting on task's exit status,
+// such as ctr run's foreground Action. Starting is an unbuffered
+// channel: Monitor's send blocks until the caller has received it, so
+// by the time that send returns, the caller is guaranteed to have
+// already stopped trusting the soon-to-be-stale exit channel of the
+// task that's about to be killed - closing the race window entirely,
+// rather than leaving the caller to guess by racing two channels.
+// Replacement then carries the new task once Monitor has created and
+// started it.
+type RestartHandoff struct {
+	Starting    chan<- struct{}
+	Replacement chan<- containerd.Task
+}
+
+// Monitor periodically executes probe against task until ctx is
+// canceled, persisting the container's health status label only once
+// a streak of consecutive failures or a success has resolved it, and
+// applying probe.OnUnhealthy once Retries consecutive failures have
+// been observed. It returns once the configured policy has acted on
+// an unhealthy container (kill and restart both invalidate task) or
+// ctx is done.
+//
+// On "restart", Monitor does not reattach itself: it hands the
+// freshly started replacement task over restart (if non-nil) and
+// returns, leaving the caller to decide when and with what context to
+// resume supervision.
+func Monitor(ctx gocontext.Context, client *containerd.Client, container containerd.Container, task containerd.Task, probe Probe, restart *RestartHandoff) {
+	if probe.StartPeriod > 0 {
+		select {
+		case <-time.After(probe.StartPeriod):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	ticker := time.NewTicker(probe.Interval)
+	defer ticker.Stop()
+
+	var failures int
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		status, err := RunOnce(ctx, client, container, task, probe)
+		if err != nil {
+			logrus.WithError(err).Warn("ctr: health probe failed to execute")
+			continue
+		}
+
+		if status == StatusHealthy {
+			failures = 0
+			recordStatus(ctx, client, container, status)
+			continue
+		}
+
+		failures++
+		if failures < probe.Retries {
+			// still within the retry budget: leave the last
+			// recorded status (starting/healthy) alone.
+			continue
+		}
+
+		recordStatus(ctx, client, container, status)
+		if err := applyPolicy(ctx, client, container, task, probe, restart); err != nil {
+			logrus.WithError(err).Warn("ctr: failed to apply on-unhealthy policy")
+		}
+		switch probe.OnUnhealthy {
+		case "kill":
+			// task is dead; nothing left to probe.
+			return
+		case "restart":
+			// task has been replaced and handed off via restart;
+			// this goroutine's handle is now stale.
+			return
+		}
+		failures = 0
+	}
+}
+
+// RunOnce execs probe.Cmd inside task a single time and returns the
+// raw observed status, without persisting it. It is shared by Monitor,
+// which only persists a status once a streak of failures/success has
+// resolved it, and the standalone `ctr healthcheck run` subcommand,
+// which persists every result it observes.
+func RunOnce(ctx gocontext.Context, client *containerd.Client, container containerd.Container, task containerd.Task, probe Probe) (string, error) {
+	pctx, cancel := gocontext.WithTimeout(ctx, probe.Timeout)
+	defer cancel()
+
+	spec, err := container.Spec(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "load container spec")
+	}
+	pspec := *spec.Process
+	pspec.Terminal = false
+	pspec.Args = probe.Cmd
+
+	execID := fmt.Sprintf("health-%d", time.Now().UnixNano())
+	process, err := task.Exec(pctx, execID, &pspec, cio.NullIO)
+	if err != nil {
+		return "", errors.Wrap(err, "create health probe process")
+	}
+	defer process.Delete(ctx)
+
+	statusC, err := process.Wait(pctx)
+	if err != nil {
+		return "", errors.Wrap(err, "wait on health probe process")
+	}
+	if err := process.Start(pctx); err != nil {
+		return "", errors.Wrap(err, "start health probe process")
+	}
+
+	status := StatusHealthy
+	select {
+	case s := <-statusC:
+		code, _, err := s.Result()
+		if err != nil {
+			return "", err
+		}
+		if code != 0 {
+			status = StatusUnhealthy
+		}
+	case <-pctx.Done():
+		process.Kill(ctx, syscall.SIGKILL)
+		status = StatusUnhealthy
+	}
+
+	return status, nil
+}
+
+// recordStatus persists status as the container's health status label
+// and publishes a HealthEvent for it. Failures to do either are
+// logged and otherwise non-fatal to the caller.
+func recordStatus(ctx gocontext.Context, client *containerd.Client, container containerd.Container, status string) {
+	if _, err := container.SetLabels(ctx, map[string]string{
+		StatusLabel: status,
+	}); err != nil {
+		logrus.WithError(err).Warn("ctr: failed to update health status label")
+	}
+	publish(ctx, client, container.ID(), status)
+}
+
+func applyPolicy(ctx gocontext.Context, client *containerd.Client, container containerd.Container, task containerd.Task, probe Probe, restart *RestartHandoff) error {
+	switch probe.OnUnhealthy {
+	case "", "none":
+		return nil
+	case "kill":
+		return task.Kill(ctx, syscall.SIGKILL)
+	case "restart":
+		if restart != nil {
+			// Block until the caller has acknowledged the handoff is
+			// starting and stopped relying on this task's exit status,
+			// before that status is made stale below.
+			select {
+			case restart.Starting <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err := task.Kill(ctx, syscall.SIGTERM); err != nil {
+			return err
+		}
+		if _, err := task.Delete(ctx, containerd.WithProcessKill); err != nil {
+			return err
+		}
+		newTask, err := tasks.NewTask(ctx, client, container, "", nil, false, "", nil)
+		if err != nil {
+			return errors.Wrap(err, "recreate task after unhealthy restart")
+		}
+		if err := newTask.Start(ctx); err != nil {
+			return err
+		}
+		if restart != nil {
+			select {
+			case restart.Replacement <- newTask:
+			case <-ctx.Done():
+			}
+		}
+		return nil
+	default:
+		return errors.Errorf("unknown on-unhealthy policy %q", probe.OnUnhealthy)
+	}
+}
+
+func publish(ctx gocontext.Context, client *containerd.Client, id, status string) {
+	e := &HealthEvent{ContainerID: id, Status: status}
+	topic := fmt.Sprintf("/containerd/health/%s", status)
+	if err := client.EventService().Publish(ctx, topic, e); err != nil {
+		logrus.WithError(err).Warn("ctr: failed to publish health event")
+	}
+}
@@ -0,0 +1,70 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// StatusLabel is the container label holding the last observed
+	// health status.
+	StatusLabel = "io.containerd.health.status"
+	// ProbeLabel is the container label holding the JSON-encoded Probe
+	// that produced StatusLabel, so that it can be re-read by an
+	// unrelated `ctr healthcheck run` invocation.
+	ProbeLabel = "io.containerd.health.probe"
+
+	// StatusStarting is reported before health-start-period has
+	// elapsed and no probe has run yet.
+	StatusStarting = "starting"
+	// StatusHealthy is reported once a probe exits zero.
+	StatusHealthy = "healthy"
+	// StatusUnhealthy is reported once a probe's consecutive failures
+	// reach the configured retry count.
+	StatusUnhealthy = "unhealthy"
+)
+
+// Probe describes a periodic health check command and the policy used
+// to interpret its results. It is stored JSON-encoded under ProbeLabel
+// so that a container created by one `ctr run` invocation can have its
+// health re-checked by a later, unrelated `ctr healthcheck run`.
+type Probe struct {
+	Cmd         []string      `json:"cmd"`
+	Interval    time.Duration `json:"interval"`
+	Timeout     time.Duration `json:"timeout"`
+	Retries     int           `json:"retries"`
+	StartPeriod time.Duration `json:"startPeriod"`
+	OnUnhealthy string        `json:"onUnhealthy"`
+}
+
+// EncodeProbe serializes a Probe for storage in a container label.
+func EncodeProbe(p Probe) (string, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// DecodeProbe parses a Probe previously serialized with EncodeProbe.
+func DecodeProbe(s string) (Probe, error) {
+	var p Probe
+	err := json.Unmarshal([]byte(s), &p)
+	return p, err
+}
@@ -0,0 +1,60 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package imagemount holds the bookkeeping shared by every path that
+// can delete a container created with `ctr run --mount type=image`:
+// the label recording its ephemeral snapshotter views and the
+// teardown of those views. It has no dependency on the run package so
+// that other container-delete paths (ctr pod rm, ctr c rm) can call
+// Remove without an import cycle back through run.
+package imagemount
+
+import (
+	gocontext "context"
+	"strings"
+
+	"github.com/containerd/containerd"
+)
+
+// Label records, on the container, the comma-separated snapshotter
+// keys of the ephemeral views `ctr run --mount type=image` created for
+// it, so any path that deletes the container can tear them down
+// instead of leaking them.
+const Label = "io.containerd.mount.image-views"
+
+// Remove tears down the ephemeral snapshotter views recorded under
+// Label for container, in the same snapshotter the container itself
+// uses. Every path that deletes such a container - run's own `--rm`,
+// `ctr pod rm`, and `ctr c rm` - must call this alongside
+// container.Delete, or the view leaks.
+func Remove(ctx gocontext.Context, client *containerd.Client, container containerd.Container) error {
+	info, err := container.Info(ctx)
+	if err != nil {
+		return err
+	}
+	keys := info.Labels[Label]
+	if keys == "" {
+		return nil
+	}
+	snapshotter := client.SnapshotService(info.Snapshotter)
+	var firstErr error
+	for _, key := range strings.Split(keys, ",") {
+		if err := snapshotter.Remove(ctx, key); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pod
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/cmd/ctr/commands"
+	"github.com/containerd/containerd/oci"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var createCommand = cli.Command{
+	Name:      "create",
+	Usage:     "create the infra container that establishes a pod's shared namespaces",
+	ArgsUsage: "ID",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "infra-image",
+			Usage: "image used for the pod's infra container",
+			Value: defaultInfraImage,
+		},
+		cli.StringFlag{
+			Name:  "snapshotter",
+			Usage: "snapshotter used to unpack the infra image",
+		},
+		cli.StringFlag{
+			Name:  "cgroup",
+			Usage: "cgroup parent shared by every container placed in this pod",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		id := context.Args().First()
+		if id == "" {
+			return errors.New("pod id must be provided")
+		}
+
+		client, ctx, cancel, err := commands.NewClient(context)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		var opts []containerd.NewContainerOpts
+		image, err := client.Pull(ctx, context.String("infra-image"), containerd.WithPullUnpack, containerd.WithPullSnapshotter(context.String("snapshotter")))
+		if err != nil {
+			return errors.Wrap(err, "pull infra image")
+		}
+
+		specOpts := []oci.SpecOpts{oci.WithImageConfig(image)}
+		if cgroup := context.String("cgroup"); cgroup != "" {
+			// The infra container gets its own cgroup under the pod's
+			// parent, so members (see pod.InfraNamespacePaths) resolve
+			// that parent back from the infra's cgroup path.
+			specOpts = append(specOpts, oci.WithCgroup(filepath.Join(cgroup, id)))
+		}
+
+		opts = append(opts,
+			containerd.WithImage(image),
+			containerd.WithSnapshotter(context.String("snapshotter")),
+			containerd.WithNewSnapshot(id+"-snapshot", image),
+			containerd.WithNewSpec(specOpts...),
+			containerd.WithContainerLabels(map[string]string{
+				LabelPodID:   id,
+				LabelPodRole: RoleInfra,
+			}),
+		)
+
+		container, err := client.NewContainer(ctx, id, opts...)
+		if err != nil {
+			return errors.Wrap(err, "create infra container")
+		}
+
+		task, err := container.NewTask(ctx, cio.NullIO)
+		if err != nil {
+			return errors.Wrap(err, "create infra task")
+		}
+		if err := task.Start(ctx); err != nil {
+			return errors.Wrap(err, "start infra task")
+		}
+
+		fmt.Fprintln(context.App.Writer, id)
+		return nil
+	},
+}
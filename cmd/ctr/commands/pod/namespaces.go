@@ -0,0 +1,93 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pod
+
+import (
+	gocontext "context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/containerd/containerd"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+)
+
+// SharedNamespaces are the namespace types a pod member joins from its
+// infra container, matching the set Kubernetes shares within a pod.
+var SharedNamespaces = []specs.LinuxNamespaceType{
+	specs.NetworkNamespace,
+	specs.IPCNamespace,
+	specs.UTSNamespace,
+}
+
+// InfraNamespacePaths resolves the /proc/<pid>/ns/* paths of podID's
+// infra task, plus the cgroup parent it runs under, so a member
+// container's spec can join them. It requires the infra container's
+// task to already be running.
+func InfraNamespacePaths(ctx gocontext.Context, client *containerd.Client, podID string) (map[specs.LinuxNamespaceType]string, string, error) {
+	infra, err := client.LoadContainer(ctx, podID)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "load pod %s infra container", podID)
+	}
+
+	info, err := infra.Info(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if info.Labels[LabelPodRole] != RoleInfra {
+		return nil, "", errors.Errorf("container %s is not a pod infra container", podID)
+	}
+
+	task, err := infra.Task(ctx, nil)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "load pod %s infra task", podID)
+	}
+	pid := task.Pid()
+
+	paths := make(map[specs.LinuxNamespaceType]string, len(SharedNamespaces))
+	for _, ns := range SharedNamespaces {
+		paths[ns] = fmt.Sprintf("/proc/%d/ns/%s", pid, nsFile(ns))
+	}
+
+	var cgroupParent string
+	if spec, err := infra.Spec(ctx); err == nil && spec.Linux != nil && spec.Linux.CgroupsPath != "" {
+		cgroupParent = filepath.Dir(spec.Linux.CgroupsPath)
+	}
+
+	return paths, cgroupParent, nil
+}
+
+func nsFile(ns specs.LinuxNamespaceType) string {
+	switch ns {
+	case specs.NetworkNamespace:
+		return "net"
+	case specs.UTSNamespace:
+		return "uts"
+	case specs.IPCNamespace:
+		return "ipc"
+	case specs.PIDNamespace:
+		return "pid"
+	case specs.MountNamespace:
+		return "mnt"
+	case specs.UserNamespace:
+		return "user"
+	case specs.CgroupNamespace:
+		return "cgroup"
+	default:
+		return string(ns)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package pod implements a lightweight grouping of containers that
+// share network, IPC, and UTS namespaces plus a cgroup parent, in the
+// style of a Kubernetes pod. Membership needs no new containerd API:
+// it is recorded entirely as labels on ordinary containers.
+package pod
+
+import (
+	"github.com/urfave/cli"
+)
+
+const (
+	// LabelPodID is set on every container that belongs to a pod,
+	// infra or member, to the pod's id.
+	LabelPodID = "io.containerd.pod.id"
+	// LabelPodRole is set to RoleInfra or RoleMember.
+	LabelPodRole = "io.containerd.pod.role"
+
+	// RoleInfra marks the container whose namespaces the rest of the
+	// pod joins.
+	RoleInfra = "infra"
+	// RoleMember marks a container that joined an existing pod.
+	RoleMember = "member"
+)
+
+// defaultInfraImage is pulled for `ctr pod create` when --infra-image
+// is not given. It only needs to hold the shared namespaces open, so
+// any minimal, long-sleeping image works.
+const defaultInfraImage = "registry.k8s.io/pause:3.9"
+
+// Command is the parent command for `ctr pod` subcommands.
+var Command = cli.Command{
+	Name:  "pod",
+	Usage: "create and manage pods: groups of containers sharing namespaces",
+	Subcommands: cli.Commands{
+		createCommand,
+		rmCommand,
+		psCommand,
+	},
+}
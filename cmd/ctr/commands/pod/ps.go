@@ -0,0 +1,60 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pod
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/containerd/containerd/cmd/ctr/commands"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var psCommand = cli.Command{
+	Name:      "ps",
+	Usage:     "list pods and their member containers",
+	ArgsUsage: "[ID]",
+	Action: func(context *cli.Context) error {
+		client, ctx, cancel, err := commands.NewClient(context)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		filter := fmt.Sprintf("labels.%q", LabelPodID)
+		if id := context.Args().First(); id != "" {
+			filter = fmt.Sprintf("labels.%q==%q", LabelPodID, id)
+		}
+
+		containers, err := client.Containers(ctx, filter)
+		if err != nil {
+			return errors.Wrap(err, "list pod containers")
+		}
+
+		w := tabwriter.NewWriter(context.App.Writer, 1, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "POD\tCONTAINER\tROLE")
+		for _, c := range containers {
+			info, err := c.Info(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", info.Labels[LabelPodID], c.ID(), info.Labels[LabelPodRole])
+		}
+		return w.Flush()
+	},
+}
@@ -0,0 +1,98 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pod
+
+import (
+	gocontext "context"
+	"fmt"
+	"syscall"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cmd/ctr/commands"
+	"github.com/containerd/containerd/cmd/ctr/commands/imagemount"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var rmCommand = cli.Command{
+	Name:      "rm",
+	Usage:     "stop and remove a pod's member containers, then its infra container",
+	ArgsUsage: "ID",
+	Action: func(context *cli.Context) error {
+		id := context.Args().First()
+		if id == "" {
+			return errors.New("pod id must be provided")
+		}
+
+		client, ctx, cancel, err := commands.NewClient(context)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		containers, err := client.Containers(ctx, fmt.Sprintf("labels.%q==%q", LabelPodID, id))
+		if err != nil {
+			return errors.Wrap(err, "list pod containers")
+		}
+
+		var infra containerd.Container
+		for _, c := range containers {
+			info, err := c.Info(ctx)
+			if err != nil {
+				return err
+			}
+			if info.Labels[LabelPodRole] == RoleInfra {
+				infra = c
+				continue
+			}
+			if err := stopAndRemove(ctx, client, c); err != nil {
+				return errors.Wrapf(err, "remove pod member %s", c.ID())
+			}
+		}
+
+		if infra != nil {
+			if err := stopAndRemove(ctx, client, infra); err != nil {
+				return errors.Wrapf(err, "remove pod infra container %s", infra.ID())
+			}
+		}
+
+		return nil
+	},
+}
+
+func stopAndRemove(ctx gocontext.Context, client *containerd.Client, container containerd.Container) error {
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		if !errdefs.IsNotFound(err) {
+			return err
+		}
+	} else {
+		if _, err := task.Delete(ctx, containerd.WithProcessKill); err != nil && !errdefs.IsNotFound(err) {
+			if err := task.Kill(ctx, syscall.SIGKILL); err != nil && !errdefs.IsNotFound(err) {
+				return err
+			}
+			if _, err := task.Delete(ctx); err != nil && !errdefs.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	if err := imagemount.Remove(ctx, client, container); err != nil {
+		return errors.Wrap(err, "remove ephemeral image-mount snapshots")
+	}
+	return container.Delete(ctx, containerd.WithSnapshotCleanup)
+}
@@ -19,15 +19,23 @@ package run
 import (
 	gocontext "context"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/containerd/console"
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/cio"
 	"github.com/containerd/containerd/cmd/ctr/commands"
+	"github.com/containerd/containerd/cmd/ctr/commands/healthcheck"
+	"github.com/containerd/containerd/cmd/ctr/commands/imagemount"
+	"github.com/containerd/containerd/cmd/ctr/commands/pod"
 	"github.com/containerd/containerd/cmd/ctr/commands/tasks"
+	"github.com/containerd/containerd/cmd/ctr/commands/volumes"
 	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/identity"
 	"github.com/containerd/containerd/oci"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
@@ -35,53 +43,337 @@ import (
 	"github.com/urfave/cli"
 )
 
-func withMounts(context *cli.Context) oci.SpecOpts {
-	return func(ctx gocontext.Context, client oci.Client, container *containers.Container, s *specs.Spec) error {
+// VolumeMountLabel records, on the container, a JSON object mapping a
+// mount destination to the named volume that backs it, so that
+// `ctr generate kube` can round-trip `--mount type=volume` mounts back
+// into a persistentVolumeClaim instead of a hostPath.
+const VolumeMountLabel = "io.containerd.mount.volumes"
+
+// ImageMountDestsLabel records, on the container, the comma-separated
+// destinations of `--mount type=image` mounts. Their source is an
+// ephemeral snapshotter view removed by imagemount.Remove, so
+// `ctr generate kube` must not reconstruct them as a stable hostPath.
+const ImageMountDestsLabel = "io.containerd.mount.image-dests"
+
+// ImageMountSourcesLabel records, on the container, a JSON object
+// mapping a `--mount type=image` destination to the original image
+// ref that was mounted there. parseMountFlag resolves that ref to an
+// ephemeral snapshotter view's mount before it ever reaches the
+// OCI spec, so this is the only place the ref survives; without it,
+// `ctr generate systemd` would reconstruct a `--mount` flag pointing
+// at a view torn down by imagemount.Remove on every restart.
+const ImageMountSourcesLabel = "io.containerd.mount.image-sources"
+
+func withMounts(context *cli.Context, client *containerd.Client) oci.SpecOpts {
+	return func(ctx gocontext.Context, ociClient oci.Client, container *containers.Container, s *specs.Spec) error {
 		mounts := make([]specs.Mount, 0)
+		var imageViewKeys, imageMountDests []string
+		volumesByDest := make(map[string]string)
+		imageSourcesByDest := make(map[string]string)
+		snapshotter := context.String("snapshotter")
 		for _, mount := range context.StringSlice("mount") {
-			m, err := parseMountFlag(mount)
+			m, viewKey, volumeName, imageRef, err := parseMountFlag(ctx, client, snapshotter, mount)
 			if err != nil {
 				return err
 			}
 			mounts = append(mounts, m)
+			if viewKey != "" {
+				imageViewKeys = append(imageViewKeys, viewKey)
+				imageMountDests = append(imageMountDests, m.Destination)
+			}
+			if volumeName != "" {
+				volumesByDest[m.Destination] = volumeName
+			}
+			if imageRef != "" {
+				imageSourcesByDest[m.Destination] = imageRef
+			}
+		}
+		if container.Labels == nil && (len(imageViewKeys) > 0 || len(volumesByDest) > 0 || len(imageSourcesByDest) > 0) {
+			container.Labels = make(map[string]string)
+		}
+		if len(imageViewKeys) > 0 {
+			container.Labels[imagemount.Label] = strings.Join(imageViewKeys, ",")
+			container.Labels[ImageMountDestsLabel] = strings.Join(imageMountDests, ",")
 		}
-		return oci.WithMounts(mounts)(ctx, client, container, s)
+		if len(volumesByDest) > 0 {
+			encoded, err := json.Marshal(volumesByDest)
+			if err != nil {
+				return err
+			}
+			container.Labels[VolumeMountLabel] = string(encoded)
+		}
+		if len(imageSourcesByDest) > 0 {
+			encoded, err := json.Marshal(imageSourcesByDest)
+			if err != nil {
+				return err
+			}
+			container.Labels[ImageMountSourcesLabel] = string(encoded)
+		}
+		return oci.WithMounts(mounts)(ctx, ociClient, container, s)
+	}
+}
+
+// mountKeysByType whitelists the keys parseMountFlag accepts for each
+// mount type, so unsupported combinations (e.g. tmpfs-size on a bind
+// mount) fail loudly instead of being silently ignored.
+var mountKeysByType = map[string]map[string]bool{
+	"bind": {
+		"type": true, "source": true, "src": true, "destination": true, "dst": true,
+		"options": true, "readonly": true, "ro": true, "relabel": true,
+		"bind-propagation": true, "bind-nonrecursive": true,
+	},
+	"tmpfs": {
+		"type": true, "destination": true, "dst": true, "options": true,
+		"readonly": true, "ro": true, "tmpfs-size": true, "tmpfs-mode": true,
+	},
+	"volume": {
+		"type": true, "source": true, "src": true, "destination": true, "dst": true,
+		"options": true, "readonly": true, "ro": true, "relabel": true,
+	},
+	"image": {
+		"type": true, "source": true, "destination": true, "dst": true,
+		"options": true, "readonly": true, "ro": true, "relabel": true,
+	},
+}
+
+// withPodNamespaces returns a SpecOpts that joins the network, IPC,
+// and UTS namespaces and cgroup parent of the --pod flag's infra
+// container, instead of creating fresh ones. The member is placed in
+// its own cgroup, named after its id, under that parent. It requires a
+// client to resolve the infra container's task pid, so - unlike
+// withMounts - it is looked up once up front, and run.Command's Action
+// applies it to the already created container's spec via
+// container.Update rather than through NewContainer's opts.
+func withPodNamespaces(ctx gocontext.Context, client *containerd.Client, context *cli.Context) (oci.SpecOpts, error) {
+	podID := context.String("pod")
+	if podID == "" {
+		return func(gocontext.Context, oci.Client, *containers.Container, *specs.Spec) error {
+			return nil
+		}, nil
+	}
+
+	paths, cgroupParent, err := pod.InfraNamespacePaths(ctx, client, podID)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve pod infra namespaces")
 	}
+
+	return func(ctx gocontext.Context, client oci.Client, c *containers.Container, s *specs.Spec) error {
+		for _, ns := range pod.SharedNamespaces {
+			if err := oci.WithLinuxNamespace(specs.LinuxNamespace{
+				Type: ns,
+				Path: paths[ns],
+			})(ctx, client, c, s); err != nil {
+				return err
+			}
+		}
+		if cgroupParent != "" {
+			if err := oci.WithCgroup(filepath.Join(cgroupParent, c.ID))(ctx, client, c, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
 }
 
-// parseMountFlag parses a mount string in the form "type=foo,source=/path,destination=/target,options=rbind:rw"
-func parseMountFlag(m string) (specs.Mount, error) {
-	mount := specs.Mount{}
+// parseMountFlag parses a mount string in the form
+// "type=foo,source=/path,destination=/target,options=rbind:rw", plus
+// type-specific keys: tmpfs-size=/tmpfs-mode= for type=tmpfs,
+// bind-propagation=/bind-nonrecursive= for type=bind, a bare
+// "readonly"/"ro" key, and relabel=shared|private for SELinux. For
+// type=volume, source names a volume created with `ctr volumes
+// create` and is resolved to its backing bind mount; the returned
+// volumeName lets `ctr generate kube` round-trip it back into a
+// persistentVolumeClaim instead of a hostPath. For type=image, source
+// is an image ref whose unpacked rootfs is mounted read-only through
+// an ephemeral snapshotter view created in snapshotter (the same one
+// the container itself uses, so imagemount.Remove tears it down from
+// the right place); the returned viewKey must be torn down (see
+// imagemount.Remove) when the container is deleted, and the returned
+// imageRef is the original ref, so callers can round-trip the
+// `--mount type=image` flag instead of the resolved view mount.
+func parseMountFlag(ctx gocontext.Context, client *containerd.Client, snapshotter string, m string) (mount specs.Mount, viewKey string, volumeName string, imageRef string, err error) {
 	r := csv.NewReader(strings.NewReader(m))
 
 	fields, err := r.Read()
 	if err != nil {
-		return mount, err
+		return mount, "", "", "", err
 	}
 
+	raw := make(map[string]string)
+	var bare []string
 	for _, field := range fields {
-		v := strings.Split(field, "=")
-		if len(v) != 2 {
-			return mount, fmt.Errorf("invalid mount specification: expected key=val")
-		}
-
-		key := v[0]
-		val := v[1]
-		switch key {
-		case "type":
-			mount.Type = val
-		case "source", "src":
-			mount.Source = val
-		case "destination", "dst":
-			mount.Destination = val
-		case "options":
-			mount.Options = strings.Split(val, ":")
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) == 1 {
+			bare = append(bare, kv[0])
+			continue
+		}
+		raw[kv[0]] = kv[1]
+	}
+
+	mount.Type = raw["type"]
+	if mount.Type == "" {
+		return mount, "", "", "", fmt.Errorf("mount option \"type\" is required")
+	}
+	allowed, ok := mountKeysByType[mount.Type]
+	if !ok {
+		return mount, "", "", "", fmt.Errorf("mount type %q not supported", mount.Type)
+	}
+	for key := range raw {
+		if !allowed[key] {
+			return mount, "", "", "", fmt.Errorf("mount option %q not supported for type %q", key, mount.Type)
+		}
+	}
+	for _, key := range bare {
+		if !allowed[key] {
+			return mount, "", "", "", fmt.Errorf("mount option %q not supported for type %q", key, mount.Type)
+		}
+	}
+
+	if src, ok := raw["source"]; ok {
+		mount.Source = src
+	} else if src, ok := raw["src"]; ok {
+		mount.Source = src
+	}
+	if dst, ok := raw["destination"]; ok {
+		mount.Destination = dst
+	} else if dst, ok := raw["dst"]; ok {
+		mount.Destination = dst
+	}
+
+	var options []string
+	if opts, ok := raw["options"]; ok {
+		options = append(options, strings.Split(opts, ":")...)
+	}
+	for _, key := range bare {
+		if key == "readonly" || key == "ro" {
+			options = append(options, "ro")
+		}
+	}
+	if relabel, ok := raw["relabel"]; ok {
+		switch relabel {
+		case "shared":
+			options = append(options, "z")
+		case "private":
+			options = append(options, "Z")
 		default:
-			return mount, fmt.Errorf("mount option %q not supported", key)
+			return mount, "", "", "", fmt.Errorf("relabel must be \"shared\" or \"private\", got %q", relabel)
 		}
 	}
 
-	return mount, nil
+	switch mount.Type {
+	case "tmpfs":
+		if size, ok := raw["tmpfs-size"]; ok {
+			options = append(options, "size="+size)
+		}
+		if mode, ok := raw["tmpfs-mode"]; ok {
+			options = append(options, "mode="+mode)
+		}
+	case "bind":
+		if prop, ok := raw["bind-propagation"]; ok {
+			switch prop {
+			case "rshared", "rslave", "rprivate":
+				options = append(options, prop)
+			default:
+				return mount, "", "", "", fmt.Errorf("bind-propagation must be one of rshared, rslave, rprivate, got %q", prop)
+			}
+		}
+		if raw["bind-nonrecursive"] == "true" {
+			if !containsOption(options, "bind") {
+				options = append(options, "bind")
+			}
+		} else if !containsOption(options, "rbind") {
+			options = append(options, "rbind")
+		}
+	case "volume":
+		if mount.Source == "" {
+			return mount, "", "", "", fmt.Errorf("mount type \"volume\" requires \"source\"")
+		}
+		if client == nil {
+			return mount, "", "", "", fmt.Errorf("mount type \"volume\" is not supported in this context")
+		}
+		volumeName = mount.Source
+		path, err := volumes.Lookup(ctx, client, volumeName)
+		if err != nil {
+			return mount, "", "", "", fmt.Errorf("look up volume %q: %w", volumeName, err)
+		}
+		mount.Type = "bind"
+		mount.Source = path
+		options = append(options, "rbind")
+	case "image":
+		if mount.Source == "" {
+			return mount, "", "", "", fmt.Errorf("mount type \"image\" requires \"source=<ref>\"")
+		}
+		if mount.Destination == "" {
+			return mount, "", "", "", fmt.Errorf("mount type \"image\" requires \"destination\"")
+		}
+		if client == nil {
+			return mount, "", "", "", fmt.Errorf("mount type \"image\" is not supported in this context")
+		}
+		dest := mount.Destination
+		imageRef = mount.Source
+		viewMount, key, err := mountImage(ctx, client, snapshotter, mount.Source)
+		if err != nil {
+			return mount, "", "", "", fmt.Errorf("mount image %q: %w", mount.Source, err)
+		}
+		mount = viewMount
+		mount.Destination = dest
+		viewKey = key
+	}
+
+	if mount.Destination == "" {
+		return mount, "", "", "", fmt.Errorf("mount option \"destination\" is required")
+	}
+	mount.Options = append(mount.Options, options...)
+	return mount, viewKey, volumeName, imageRef, nil
+}
+
+// containsOption reports whether opt is already present among options,
+// so callers deriving an option from another flag (e.g. "rbind" from
+// the absence of bind-nonrecursive) don't append a duplicate on top
+// of one the user already passed via options=.
+func containsOption(options []string, opt string) bool {
+	for _, o := range options {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// mountImage creates an ephemeral, read-only view of ref's unpacked
+// rootfs in snapshotter - the same snapshotter the container being
+// created uses, so imagemount.Remove later removes the view from the
+// snapshotter it actually lives in instead of leaking it - and returns
+// the resulting mount plus the snapshot key the caller must remove
+// (see imagemount.Remove) once the owning container is deleted.
+func mountImage(ctx gocontext.Context, client *containerd.Client, snapshotter string, ref string) (specs.Mount, string, error) {
+	if snapshotter == "" {
+		snapshotter = containerd.DefaultSnapshotter
+	}
+	image, err := client.GetImage(ctx, ref)
+	if err != nil {
+		return specs.Mount{}, "", err
+	}
+	diffIDs, err := image.RootFS(ctx)
+	if err != nil {
+		return specs.Mount{}, "", err
+	}
+
+	key := fmt.Sprintf("ctr-image-mount-%s-%d", image.Name(), time.Now().UnixNano())
+	snapshotService := client.SnapshotService(snapshotter)
+	mounts, err := snapshotService.View(ctx, key, identity.ChainID(diffIDs).String())
+	if err != nil {
+		return specs.Mount{}, "", err
+	}
+	if len(mounts) != 1 {
+		return specs.Mount{}, "", fmt.Errorf("unexpected mount count %d for image view", len(mounts))
+	}
+
+	return specs.Mount{
+		Type:    mounts[0].Type,
+		Source:  mounts[0].Source,
+		Options: mounts[0].Options,
+	}, key, nil
 }
 
 // Command runs a container
@@ -119,6 +411,38 @@ var Command = cli.Command{
 			Name:  "platform",
 			Usage: "run image for specific platform",
 		},
+		cli.StringFlag{
+			Name:  "health-cmd",
+			Usage: "command to run inside the container to check health, enables periodic health checking",
+		},
+		cli.DurationFlag{
+			Name:  "health-interval",
+			Usage: "time between running the health check",
+			Value: 30 * time.Second,
+		},
+		cli.DurationFlag{
+			Name:  "health-timeout",
+			Usage: "maximum time to allow one health check to run",
+			Value: 30 * time.Second,
+		},
+		cli.IntFlag{
+			Name:  "health-retries",
+			Usage: "consecutive failures needed to report unhealthy",
+			Value: 3,
+		},
+		cli.DurationFlag{
+			Name:  "health-start-period",
+			Usage: "initialization time before health check failures count towards health-retries",
+		},
+		cli.StringFlag{
+			Name:  "on-unhealthy",
+			Usage: "policy to apply once the container is reported unhealthy (restart|kill|none)",
+			Value: "none",
+		},
+		cli.StringFlag{
+			Name:  "pod",
+			Usage: "join the network, IPC, and UTS namespaces and cgroup parent of the named pod's infra container (see `ctr pod create`)",
+		},
 	}, append(platformRunFlags, append(commands.SnapshotterFlags, commands.ContainerFlags...)...)...),
 	Action: func(context *cli.Context) error { //ctr run 命令函数入口
 		var (
@@ -164,7 +488,36 @@ var Command = cli.Command{
 			return err
 		}
 		if context.Bool("rm") && !detach {
-			defer container.Delete(ctx, containerd.WithSnapshotCleanup)
+			defer func() {
+				if err := imagemount.Remove(ctx, client, container); err != nil {
+					logrus.WithError(err).Warn("ctr: failed to remove ephemeral image-mount snapshots")
+				}
+				container.Delete(ctx, containerd.WithSnapshotCleanup)
+			}()
+		}
+		if podID := context.String("pod"); podID != "" {
+			if _, err := container.SetLabels(ctx, map[string]string{
+				pod.LabelPodID:   podID,
+				pod.LabelPodRole: pod.RoleMember,
+			}); err != nil {
+				return errors.Wrap(err, "label pod member")
+			}
+			// The task containerd is about to create reads its spec
+			// back from the container record, not from the SpecOpts
+			// NewContainer assembled, so joining the infra container's
+			// namespaces still takes effect even applied here, after
+			// NewContainer but before any task exists.
+			podOpts, err := withPodNamespaces(ctx, client, context)
+			if err != nil {
+				return err
+			}
+			spec, err := container.Spec(ctx)
+			if err != nil {
+				return errors.Wrap(err, "load container spec")
+			}
+			if err := container.Update(ctx, containerd.UpdateContainerOpts(containerd.WithSpec(spec, podOpts))); err != nil {
+				return errors.Wrap(err, "join pod namespaces")
+			}
 		}
 		var con console.Console
 		if tty {
@@ -202,6 +555,55 @@ var Command = cli.Command{
 		if err := task.Start(ctx); err != nil {
 			return err
 		}
+		var probe healthcheck.Probe
+		var restart *healthcheck.RestartHandoff
+		var startingC chan struct{}
+		var replacedC chan containerd.Task
+		if healthCmd := context.String("health-cmd"); healthCmd != "" {
+			if context.Duration("health-interval") <= 0 {
+				return errors.New("health-interval must be positive")
+			}
+			if context.Duration("health-timeout") <= 0 {
+				return errors.New("health-timeout must be positive")
+			}
+			probe = healthcheck.Probe{
+				Cmd:         strings.Fields(healthCmd),
+				Interval:    context.Duration("health-interval"),
+				Timeout:     context.Duration("health-timeout"),
+				Retries:     context.Int("health-retries"),
+				StartPeriod: context.Duration("health-start-period"),
+				OnUnhealthy: context.String("on-unhealthy"),
+			}
+			encoded, err := healthcheck.EncodeProbe(probe)
+			if err != nil {
+				return errors.Wrap(err, "encode health probe")
+			}
+			if _, err := container.SetLabels(ctx, map[string]string{
+				healthcheck.ProbeLabel:  encoded,
+				healthcheck.StatusLabel: healthcheck.StatusStarting,
+			}); err != nil {
+				return errors.Wrap(err, "store health probe")
+			}
+			// ctr is a short-lived CLI: this goroutine only supervises
+			// the container for as long as this invocation stays
+			// attached (i.e. not --detach). `ctr healthcheck run`
+			// re-executes the stored probe out-of-process, e.g. from
+			// a systemd timer, against a container started elsewhere.
+			//
+			// While attached, this Action is blocked waiting on the
+			// task's exit below, so an --on-unhealthy=restart can't
+			// just swap the task out from under it: Monitor blocks on
+			// startingC until the wait loop below has acknowledged it
+			// and stopped trusting statusC, only then killing the old
+			// task, and hands the replacement task back over
+			// replacedC once it's started.
+			if !detach {
+				startingC = make(chan struct{})
+				replacedC = make(chan containerd.Task, 1)
+				restart = &healthcheck.RestartHandoff{Starting: startingC, Replacement: replacedC}
+			}
+			go healthcheck.Monitor(ctx, client, container, task, probe, restart)
+		}
 		if detach {
 			return nil
 		}
@@ -213,7 +615,27 @@ var Command = cli.Command{
 			sigc := commands.ForwardAllSignals(ctx, task)
 			defer commands.StopCatch(sigc)
 		}
-		status := <-statusC
+		var status containerd.ExitStatus
+		for {
+			select {
+			case status = <-statusC:
+			case <-startingC:
+				// The health monitor is about to kill and replace this
+				// task as part of an --on-unhealthy=restart: stop
+				// trusting its exit status until the replacement shows
+				// up on replacedC.
+				statusC = nil
+				continue
+			case newTask := <-replacedC:
+				task = newTask
+				if statusC, err = task.Wait(ctx); err != nil {
+					return err
+				}
+				go healthcheck.Monitor(ctx, client, container, task, probe, restart)
+				continue
+			}
+			break
+		}
 		code, _, err := status.Result()
 		if err != nil {
 			return err
@@ -0,0 +1,150 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package run
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseMountFlagBind(t *testing.T) {
+	tests := []struct {
+		name        string
+		flag        string
+		wantSource  string
+		wantDest    string
+		wantOptions []string
+	}{
+		{
+			name:        "basic bind defaults to rbind",
+			flag:        "type=bind,source=/host,destination=/container",
+			wantSource:  "/host",
+			wantDest:    "/container",
+			wantOptions: []string{"rbind"},
+		},
+		{
+			name:        "user-supplied rbind is not duplicated",
+			flag:        "type=bind,source=/host,destination=/container,options=rbind:rw",
+			wantSource:  "/host",
+			wantDest:    "/container",
+			wantOptions: []string{"rbind", "rw"},
+		},
+		{
+			name:        "bind-nonrecursive",
+			flag:        "type=bind,source=/host,destination=/container,bind-nonrecursive=true",
+			wantSource:  "/host",
+			wantDest:    "/container",
+			wantOptions: []string{"bind"},
+		},
+		{
+			name:        "bind-nonrecursive not duplicated",
+			flag:        "type=bind,source=/host,destination=/container,bind-nonrecursive=true,options=bind",
+			wantSource:  "/host",
+			wantDest:    "/container",
+			wantOptions: []string{"bind"},
+		},
+		{
+			name:        "readonly bare option",
+			flag:        "type=bind,source=/host,destination=/container,readonly",
+			wantSource:  "/host",
+			wantDest:    "/container",
+			wantOptions: []string{"ro", "rbind"},
+		},
+		{
+			name:        "relabel shared",
+			flag:        "type=bind,source=/host,destination=/container,relabel=shared",
+			wantSource:  "/host",
+			wantDest:    "/container",
+			wantOptions: []string{"z", "rbind"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mount, viewKey, volumeName, imageRef, err := parseMountFlag(context.Background(), nil, "", tt.flag)
+			if err != nil {
+				t.Fatalf("parseMountFlag(%q) returned error: %v", tt.flag, err)
+			}
+			if viewKey != "" || volumeName != "" || imageRef != "" {
+				t.Errorf("parseMountFlag(%q) unexpectedly set viewKey=%q volumeName=%q imageRef=%q", tt.flag, viewKey, volumeName, imageRef)
+			}
+			if mount.Source != tt.wantSource {
+				t.Errorf("source = %q, want %q", mount.Source, tt.wantSource)
+			}
+			if mount.Destination != tt.wantDest {
+				t.Errorf("destination = %q, want %q", mount.Destination, tt.wantDest)
+			}
+			if len(mount.Options) != len(tt.wantOptions) {
+				t.Fatalf("options = %v, want %v", mount.Options, tt.wantOptions)
+			}
+			for i, o := range tt.wantOptions {
+				if mount.Options[i] != o {
+					t.Errorf("options = %v, want %v", mount.Options, tt.wantOptions)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestParseMountFlagTmpfs(t *testing.T) {
+	mount, _, _, _, err := parseMountFlag(context.Background(), nil, "", "type=tmpfs,destination=/tmp,tmpfs-size=64m,tmpfs-mode=1777")
+	if err != nil {
+		t.Fatalf("parseMountFlag returned error: %v", err)
+	}
+	want := []string{"size=64m", "mode=1777"}
+	if len(mount.Options) != len(want) {
+		t.Fatalf("options = %v, want %v", mount.Options, want)
+	}
+	for i, o := range want {
+		if mount.Options[i] != o {
+			t.Errorf("options = %v, want %v", mount.Options, want)
+			break
+		}
+	}
+}
+
+func TestParseMountFlagErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		flag string
+	}{
+		{"missing type", "source=/host,destination=/container"},
+		{"unsupported type", "type=nope,destination=/container"},
+		{"unsupported option for type", "type=tmpfs,destination=/tmp,bind-propagation=rshared"},
+		{"missing destination", "type=bind,source=/host"},
+		{"volume requires source", "type=volume,destination=/container"},
+		{"bad bind-propagation", "type=bind,source=/host,destination=/container,bind-propagation=bogus"},
+		{"bad relabel", "type=bind,source=/host,destination=/container,relabel=bogus"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, _, err := parseMountFlag(context.Background(), nil, "", tt.flag); err == nil {
+				t.Errorf("parseMountFlag(%q) succeeded, want error", tt.flag)
+			}
+		})
+	}
+}
+
+func TestContainsOption(t *testing.T) {
+	options := []string{"rbind", "ro"}
+	if !containsOption(options, "rbind") {
+		t.Error("containsOption(options, \"rbind\") = false, want true")
+	}
+	if containsOption(options, "nonrecursive") {
+		t.Error("containsOption(options, \"nonrecursive\") = true, want false")
+	}
+}
@@ -0,0 +1,119 @@
+//go:build !windows
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package run
+
+import (
+	gocontext "context"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/oci"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var platformRunFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "net-host",
+		Usage: "enable host networking for the container",
+	},
+	cli.BoolFlag{
+		Name:  "privileged",
+		Usage: "run privileged container",
+	},
+	cli.BoolFlag{
+		Name:  "read-only",
+		Usage: "set the containers filesystem as readonly",
+	},
+}
+
+func getNewTaskOpts(context *cli.Context) []containerd.NewTaskOpts {
+	var opts []containerd.NewTaskOpts
+	if context.Bool("null-io") {
+		opts = append(opts, containerd.WithNoNewKeyring)
+	}
+	return opts
+}
+
+// NewContainer creates a new container from the image or spec config
+// named by context, building its OCI spec from the same --mount,
+// --net-host, --privileged, --read-only, --cwd, --env, and --cgroup
+// flags `ctr run` exposes. --pod namespace joining happens afterwards,
+// in run.Command's Action, since it needs the container record to
+// already exist before it can be patched onto the spec via
+// container.Update.
+func NewContainer(ctx gocontext.Context, client *containerd.Client, context *cli.Context) (containerd.Container, error) {
+	var id string
+	if context.IsSet("config") {
+		id = context.Args().First()
+	} else {
+		id = context.Args().Get(1)
+	}
+
+	var (
+		opts  []oci.SpecOpts
+		cOpts []containerd.NewContainerOpts
+	)
+
+	if context.IsSet("config") {
+		opts = append(opts, oci.WithSpecFromFile(context.String("config")))
+	} else {
+		ref := context.Args().First()
+		snapshotter := context.String("snapshotter")
+
+		image, err := client.GetImage(ctx, ref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get image %s", ref)
+		}
+
+		opts = append(opts, oci.WithImageConfig(image))
+		cOpts = append(cOpts,
+			containerd.WithImage(image),
+			containerd.WithSnapshotter(snapshotter),
+			containerd.WithNewSnapshot(id+"-snapshot", image),
+		)
+
+		if context.Bool("net-host") {
+			opts = append(opts, oci.WithHostNamespace(specs.NetworkNamespace), oci.WithHostHostsFile, oci.WithHostResolvconf)
+		}
+		if context.Bool("privileged") {
+			opts = append(opts, oci.WithPrivileged)
+		}
+		if context.Bool("read-only") {
+			opts = append(opts, oci.WithRootFSReadonly())
+		}
+		if cwd := context.String("cwd"); cwd != "" {
+			opts = append(opts, oci.WithProcessCwd(cwd))
+		}
+		if cgroup := context.String("cgroup"); context.IsSet("cgroup") {
+			opts = append(opts, oci.WithCgroup(cgroup))
+		}
+		for _, env := range context.StringSlice("env") {
+			opts = append(opts, oci.WithEnv([]string{env}))
+		}
+		if args := context.Args().Tail(); len(args) > 1 {
+			opts = append(opts, oci.WithProcessArgs(args[1:]...))
+		}
+	}
+
+	opts = append(opts, withMounts(context, client))
+	cOpts = append(cOpts, containerd.WithNewSpec(opts...))
+
+	return client.NewContainer(ctx, id, cOpts...)
+}
@@ -0,0 +1,165 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package volumes implements `ctr volumes`, named host directories
+// that `ctr run --mount type=volume,source=<name>,...` can attach to a
+// container without the caller needing to know the backing path.
+package volumes
+
+import (
+	gocontext "context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/content"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// rootDir holds each named volume's backing directory. The name ->
+// path mapping itself lives in containerd's content store (see
+// nameLabel/pathLabel below), not a local file, so a volume is visible
+// to - and garbage-collected with - the daemon that owns it rather
+// than only to whichever host ran `ctr volumes create`.
+const rootDir = "/var/lib/containerd/io.containerd.ctr.v1.volumes"
+
+const (
+	// nameLabel marks a content store blob as the record for a named
+	// volume and holds that name.
+	nameLabel = "containerd.io/volume.name"
+	// pathLabel holds the volume's backing directory.
+	pathLabel = "containerd.io/volume.path"
+)
+
+// find returns the content.Info recording name, if one exists.
+func find(ctx gocontext.Context, store content.Store, name string) (content.Info, bool, error) {
+	var (
+		found content.Info
+		ok    bool
+	)
+	filter := "labels.\"" + nameLabel + "\"==\"" + name + "\""
+	if err := store.Walk(ctx, func(info content.Info) error {
+		found, ok = info, true
+		return nil
+	}, filter); err != nil {
+		return content.Info{}, false, err
+	}
+	return found, ok, nil
+}
+
+// Lookup returns the host path backing a named volume. Used by
+// `ctr run --mount type=volume,source=<name>` to materialize the bind
+// mount that type resolves to.
+func Lookup(ctx gocontext.Context, client *containerd.Client, name string) (string, error) {
+	info, ok, err := find(ctx, client.ContentStore(), name)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errors.Errorf("volume %q not found", name)
+	}
+	return info.Labels[pathLabel], nil
+}
+
+// validateVolumeName rejects names that would let filepath.Join(rootDir,
+// name) escape rootDir, since name is used directly as a path
+// component for both the backing directory and (via RemoveAll) its
+// deletion.
+func validateVolumeName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return errors.Errorf("invalid volume name %q", name)
+	}
+	return nil
+}
+
+// Create makes a new named volume and returns its backing path.
+func Create(ctx gocontext.Context, client *containerd.Client, name string) (string, error) {
+	if err := validateVolumeName(name); err != nil {
+		return "", err
+	}
+	store := client.ContentStore()
+	if _, ok, err := find(ctx, store, name); err != nil {
+		return "", err
+	} else if ok {
+		return "", errors.Errorf("volume %q already exists", name)
+	}
+
+	path := filepath.Join(rootDir, name)
+	if err := os.MkdirAll(path, 0711); err != nil {
+		return "", err
+	}
+
+	if err := record(ctx, store, name, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// record writes name's content store entry, labeled with the name and
+// backing path so find can look it up and List can enumerate it.
+func record(ctx gocontext.Context, store content.Store, name, path string) error {
+	data := []byte(name)
+	dgst := digest.FromBytes(data)
+
+	w, err := store.Writer(ctx, content.WithRef("ctr-volume-"+name))
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Commit(ctx, int64(len(data)), dgst, content.WithLabels(map[string]string{
+		nameLabel: name,
+		pathLabel: path,
+	}))
+}
+
+// Remove deletes a named volume and its contents.
+func Remove(ctx gocontext.Context, client *containerd.Client, name string) error {
+	if err := validateVolumeName(name); err != nil {
+		return err
+	}
+	store := client.ContentStore()
+	info, ok, err := find(ctx, store, name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("volume %q not found", name)
+	}
+	if err := os.RemoveAll(info.Labels[pathLabel]); err != nil {
+		return err
+	}
+	return store.Delete(ctx, info.Digest)
+}
+
+// List returns every named volume and its backing path.
+func List(ctx gocontext.Context, client *containerd.Client) (map[string]string, error) {
+	vols := make(map[string]string)
+	err := client.ContentStore().Walk(ctx, func(info content.Info) error {
+		name, ok := info.Labels[nameLabel]
+		if !ok {
+			return nil
+		}
+		vols[name] = info.Labels[pathLabel]
+		return nil
+	}, "labels.\""+nameLabel+"\"")
+	return vols, err
+}
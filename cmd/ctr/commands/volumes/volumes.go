@@ -0,0 +1,100 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumes
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/containerd/containerd/cmd/ctr/commands"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+// Command is the parent command for `ctr volumes` subcommands.
+var Command = cli.Command{
+	Name:  "volumes",
+	Usage: "create and manage named volumes for ctr run --mount type=volume",
+	Subcommands: cli.Commands{
+		createCommand,
+		rmCommand,
+		lsCommand,
+	},
+}
+
+var createCommand = cli.Command{
+	Name:      "create",
+	Usage:     "create a named volume",
+	ArgsUsage: "NAME",
+	Action: func(context *cli.Context) error {
+		name := context.Args().First()
+		if name == "" {
+			return errors.New("volume name must be provided")
+		}
+		client, ctx, cancel, err := commands.NewClient(context)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		path, err := Create(ctx, client, name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(context.App.Writer, path)
+		return nil
+	},
+}
+
+var rmCommand = cli.Command{
+	Name:      "rm",
+	Usage:     "remove a named volume",
+	ArgsUsage: "NAME",
+	Action: func(context *cli.Context) error {
+		name := context.Args().First()
+		if name == "" {
+			return errors.New("volume name must be provided")
+		}
+		client, ctx, cancel, err := commands.NewClient(context)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		return Remove(ctx, client, name)
+	},
+}
+
+var lsCommand = cli.Command{
+	Name:  "ls",
+	Usage: "list named volumes",
+	Action: func(context *cli.Context) error {
+		client, ctx, cancel, err := commands.NewClient(context)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		vols, err := List(ctx, client)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(context.App.Writer, 1, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tPATH")
+		for name, path := range vols {
+			fmt.Fprintf(w, "%s\t%s\n", name, path)
+		}
+		return w.Flush()
+	},
+}